@@ -0,0 +1,207 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package x509 implements the pki.PublicKey and pki.Signature interfaces for
+// keys and signatures that are encoded as raw public keys or X.509
+// certificates.
+package x509
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// EmailAddressOID is the OID for the emailAddress attribute in an X.509
+// Subject's distinguished name, used as a fallback when a certificate does
+// not populate the SAN email addresses extension.
+var EmailAddressOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// PublicKey implements the pki.PublicKey interface for keys and certificates
+// supported by crypto/x509: ECDSA, RSA and Ed25519.
+type PublicKey struct {
+	key       crypto.PublicKey
+	certChain []*x509.Certificate
+}
+
+// NewPublicKey parses either a PEM-encoded "PUBLIC KEY" block or a PEM-encoded
+// "CERTIFICATE" chain and returns the resulting PublicKey.
+func NewPublicKey(r io.Reader) (*PublicKey, error) {
+	rawBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(rawBytes)
+	if block == nil {
+		return nil, errors.New("invalid public key: must be PEM encoded")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		certs, err := x509.ParseCertificates(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) == 0 {
+			return nil, errors.New("no certificates found in PEM block")
+		}
+		return &PublicKey{key: certs[0].PublicKey, certChain: certs}, nil
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+			return &PublicKey{key: key}, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type: %T", key)
+		}
+	}
+}
+
+// CanonicalValue implements the pki.PublicKey interface.
+func (k PublicKey) CanonicalValue() ([]byte, error) {
+	if len(k.certChain) > 0 {
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: k.certChain[0].Raw,
+		}), nil
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(k.key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	}), nil
+}
+
+// EmailAddresses returns the email addresses bound to the leaf certificate,
+// falling back to the emailAddress attribute of the Subject DN. It returns
+// nil if the key was not parsed from a certificate.
+func (k PublicKey) EmailAddresses() []string {
+	if len(k.certChain) == 0 {
+		return nil
+	}
+
+	cert := k.certChain[0]
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses
+	}
+
+	var emails []string
+	for _, atv := range cert.Subject.Names {
+		if atv.Type.Equal(EmailAddressOID) {
+			if v, ok := atv.Value.(string); ok {
+				emails = append(emails, v)
+			}
+		}
+	}
+	return emails
+}
+
+// Signature implements the pki.Signature interface for raw signatures that
+// verify against an x509.PublicKey.
+type Signature struct {
+	signature []byte
+}
+
+// NewSignature reads and stores the raw signature bytes.
+func NewSignature(r io.Reader) (*Signature, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{signature: b}, nil
+}
+
+// CanonicalValue implements the pki.Signature interface.
+func (s Signature) CanonicalValue() ([]byte, error) {
+	return s.signature, nil
+}
+
+// Verify checks the signature against the already-hashed digest in r, using
+// the public key or certificate in k. hashAlg identifies the hash that
+// produced the digest; RSA verification needs it explicitly, while ECDSA
+// verifies the digest bytes as-is regardless of which hash produced them.
+//
+// hashedrekord entries only ever carry a digest of the signed content, never
+// the content itself, so this is the only verification mode this type needs
+// for ECDSA and RSA.
+//
+// Ed25519 is the exception, and callers MUST NOT treat it like the other two:
+// Ed25519 has no pre-hashed verification mode, so the "digest" in r is
+// verified as if it were the entire signed message. That only succeeds if
+// the signer deliberately signed the digest bytes rather than the artifact
+// itself - a non-standard convention that ordinary Ed25519 signers (e.g.
+// cosign/TUF, which sign the artifact) do not follow. An artifact signed the
+// normal way will never verify here; see TestCrossFieldValidation's
+// "ed25519 signature over the raw message is rejected" case.
+func (s *Signature) Verify(r io.Reader, k interface{}, hashAlg crypto.Hash) error {
+	if len(s.signature) == 0 {
+		return errors.New("hashedrekord signature has not been initialized")
+	}
+	if r == nil {
+		return errors.New("nil reader passed to Verify")
+	}
+
+	pubKey, ok := k.(*PublicKey)
+	if !ok {
+		return fmt.Errorf("cannot verify hashedrekord signature with a non-x509 public key: %T", k)
+	}
+
+	digest, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	switch pub := pubKey.key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, s.signature) {
+			return errors.New("ecdsa signature verification failed")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(pub, hashAlg, digest, s.signature) == nil {
+			return nil
+		}
+		if err := rsa.VerifyPSS(pub, hashAlg, digest, s.signature, nil); err != nil {
+			return fmt.Errorf("rsa signature verification failed: %w", err)
+		}
+		return nil
+	case ed25519.PublicKey:
+		// digest is verified as the signed message itself; see the Verify
+		// doc comment above for why this requires a non-standard signer.
+		if !ed25519.Verify(pub, digest, s.signature) {
+			return errors.New("ed25519 signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid public key type: %T", pub)
+	}
+}
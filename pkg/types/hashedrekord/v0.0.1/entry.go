@@ -0,0 +1,273 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package hashedrekord
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	_ "golang.org/x/crypto/sha3" // registers crypto.SHA3_256 and crypto.SHA3_512
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/pki"
+	"github.com/sigstore/rekor/pkg/pki/x509"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+const (
+	APIVERSION = "0.0.1"
+
+	// inTotoStatementType is the "_type" an in-toto Statement sets on itself,
+	// used to recognize a hashedrekord upload whose signed content is an
+	// attestation rather than an opaque artifact.
+	inTotoStatementType = "https://in-toto.io/Statement/v0.1"
+)
+
+// hashAlgorithm describes a hash function that hashedrekord accepts for the
+// artifact digest: the crypto.Hash used to verify RSA signatures against it,
+// and the hex-encoded length a digest of that hash must have.
+type hashAlgorithm struct {
+	Hash   crypto.Hash
+	HexLen int
+}
+
+// hashAlgorithms maps the "data.hash.algorithm" values hashedrekord accepts
+// to the hash they identify; validate() has no per-algorithm special casing.
+//
+// Every key here must also appear in the OpenAPI "data.hash.algorithm" enum
+// (pkg/generated/models.HashedrekordV001SchemaDataHash), or go-swagger's
+// request-model validation rejects the value before it ever reaches
+// validate(). Adding an entry here without extending that enum leaves the
+// algorithm unreachable from the API.
+var hashAlgorithms = map[string]hashAlgorithm{
+	"sha256":   {crypto.SHA256, sha256.Size * 2},
+	"sha384":   {crypto.SHA384, crypto.SHA384.Size() * 2},
+	"sha512":   {crypto.SHA512, crypto.SHA512.Size() * 2},
+	"sha3-256": {crypto.SHA3_256, crypto.SHA3_256.Size() * 2},
+	"sha3-512": {crypto.SHA3_512, crypto.SHA3_512.Size() * 2},
+}
+
+func init() {
+	if err := types.NewEntryType(APIVERSION, NewEntry); err != nil {
+		log.Logger.Panic(err)
+	}
+}
+
+// V001Entry represents a hashedrekord entry: a detached signature and public
+// key over the hash of an artifact, without the artifact's raw content ever
+// being uploaded.
+type V001Entry struct {
+	HashedRekordObj models.HashedrekordV001Schema
+	keyObj          pki.PublicKey
+	sigObj          pki.Signature
+}
+
+func NewEntry() types.EntryImpl {
+	return &V001Entry{}
+}
+
+func (v V001Entry) APIVersion() string {
+	return APIVERSION
+}
+
+// Unmarshal decodes the supplied ProposedEntry's spec into the type's
+// internal schema representation and validates it.
+func (v *V001Entry) Unmarshal(pe interface{}) error {
+	entry, ok := pe.(*models.Hashedrekord)
+	if !ok {
+		return errors.New("cannot unmarshal non Hashedrekord types")
+	}
+
+	if err := types.DecodeEntry(entry.Spec, &v.HashedRekordObj); err != nil {
+		return err
+	}
+
+	_, _, err := v.validate()
+	return err
+}
+
+// validate checks that the entry's required fields are populated and
+// internally consistent, returning the parsed public key and signature so
+// that callers can reuse the verification work already done here.
+func (v *V001Entry) validate() (pki.PublicKey, pki.Signature, error) {
+	sig := v.HashedRekordObj.Signature
+	if sig == nil {
+		return nil, nil, errors.New("missing signature")
+	}
+	if len(sig.Content) == 0 {
+		return nil, nil, errors.New("signature content must be specified")
+	}
+
+	key := sig.PublicKey
+	if key == nil {
+		return nil, nil, errors.New("missing public key")
+	}
+	if len(key.Content) == 0 {
+		return nil, nil, errors.New("public key content must be specified")
+	}
+
+	keyObj, err := x509.NewPublicKey(bytes.NewReader(key.Content))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sigObj, err := x509.NewSignature(bytes.NewReader(sig.Content))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := v.HashedRekordObj.Data
+	if data == nil {
+		return nil, nil, errors.New("missing data")
+	}
+	if data.Hash == nil || data.Hash.Algorithm == nil || data.Hash.Value == nil {
+		return nil, nil, errors.New("missing data hash")
+	}
+
+	algorithm := swag.StringValue(data.Hash.Algorithm)
+	alg, ok := hashAlgorithms[algorithm]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported hash algorithm: %v", algorithm)
+	}
+
+	hashVal := swag.StringValue(data.Hash.Value)
+	if len(hashVal) != alg.HexLen {
+		return nil, nil, fmt.Errorf("invalid %v hash length %v", algorithm, len(hashVal))
+	}
+	digest, err := hex.DecodeString(hashVal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// hashedrekord only ever carries the digest of the signed content, so
+	// the signer's key/signature are verified directly against it, rather
+	// than against a message that would need re-hashing.
+	if err := sigObj.Verify(bytes.NewReader(digest), keyObj, alg.Hash); err != nil {
+		return nil, nil, err
+	}
+
+	v.keyObj = keyObj
+	v.sigObj = sigObj
+
+	return keyObj, sigObj, nil
+}
+
+// Canonicalize validates the entry and marshals it into its canonical,
+// API-version-stamped form suitable for storage in the log.
+func (v *V001Entry) Canonicalize(_ context.Context) ([]byte, error) {
+	if _, _, err := v.validate(); err != nil {
+		return nil, types.ValidationError(err)
+	}
+
+	// Data.Content, if present, is not checked against the hash by validate();
+	// it is only read by IndexKeys, to look for an in-toto Statement.
+	// hashedrekord never persists the artifact itself, only its digest.
+	canonicalEntry := models.HashedrekordV001Schema{
+		Data: &models.HashedrekordV001SchemaData{
+			Hash: v.HashedRekordObj.Data.Hash,
+		},
+		Signature: v.HashedRekordObj.Signature,
+	}
+
+	itemBytes, err := swag.WriteJSON(canonicalEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	returnVal := models.Hashedrekord{
+		APIVersion: swag.String(APIVERSION),
+		Spec:       json.RawMessage(itemBytes),
+	}
+
+	return swag.WriteJSON(returnVal)
+}
+
+// IndexKeys returns the set of keys that this entry should be indexed under:
+// a "sha256:"-prefixed hash of the signer's public key/certificate, the
+// digest of the signed artifact, and, when Data.Content is an in-toto
+// Statement, an "<alg>:<digest>" key for each of its subjects. The key index uses the same "sha256:"
+// prefix as v0.0.2 so that a key which signs both a plain hashedrekord and a
+// DSSE envelope is indexed identically across versions.
+func (v *V001Entry) IndexKeys() ([]string, error) {
+	var result []string
+
+	// Index keys are derived from the public key bytes themselves, not from
+	// a successful signature verification, so entries can still be looked up
+	// by key even if validate() would otherwise reject them.
+	keyObj := v.keyObj
+	if keyObj == nil && v.HashedRekordObj.Signature != nil && v.HashedRekordObj.Signature.PublicKey != nil {
+		var err error
+		keyObj, err = x509.NewPublicKey(bytes.NewReader(v.HashedRekordObj.Signature.PublicKey.Content))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if keyObj != nil {
+		key, err := keyObj.CanonicalValue()
+		if err != nil {
+			log.Logger.Error(err)
+		} else {
+			keyHash := sha256.Sum256(key)
+			result = append(result, "sha256:"+hex.EncodeToString(keyHash[:]))
+		}
+	}
+
+	if v.HashedRekordObj.Data != nil && v.HashedRekordObj.Data.Hash != nil {
+		hashKey := strings.ToLower(fmt.Sprintf("%s:%s",
+			swag.StringValue(v.HashedRekordObj.Data.Hash.Algorithm),
+			swag.StringValue(v.HashedRekordObj.Data.Hash.Value)))
+		result = append(result, hashKey)
+	}
+
+	// If the uploaded content is itself an in-toto Statement, also index it
+	// by each subject's digest so it can be found by artifact hash even
+	// though only the attestation, not the artifact, was logged.
+	if v.HashedRekordObj.Data != nil && len(v.HashedRekordObj.Data.Content) > 0 {
+		result = append(result, inTotoSubjectIndexKeys(v.HashedRekordObj.Data.Content)...)
+	}
+
+	return result, nil
+}
+
+// inTotoSubjectIndexKeys returns an "<alg>:<digest>" index key for every
+// subject digest in content, if content parses as an in-toto Statement. It
+// returns nil, without error, for content that isn't an in-toto Statement.
+func inTotoSubjectIndexKeys(content []byte) []string {
+	var stmt in_toto.Statement
+	if err := json.Unmarshal(content, &stmt); err != nil || stmt.Type != inTotoStatementType {
+		return nil
+	}
+
+	var keys []string
+	for _, subject := range stmt.Subject {
+		for alg, digest := range subject.Digest {
+			keys = append(keys, strings.ToLower(fmt.Sprintf("%s:%s", alg, digest)))
+		}
+	}
+	return keys
+}
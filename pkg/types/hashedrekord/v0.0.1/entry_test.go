@@ -23,7 +23,9 @@ import (
 	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/hex"
@@ -74,17 +76,29 @@ func TestCrossFieldValidation(t *testing.T) {
 		Type:  "PUBLIC KEY",
 	})
 
-	// testing lack of support for ed25519
-	invalidEdPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	edPubKey, edPrivKey, err := ed25519.GenerateKey(rand.Reader)
 	if err != nil {
 		t.Fatal(err)
 	}
-	invalidDer, err := x509.MarshalPKIXPublicKey(invalidEdPubKey)
+	edDer, err := x509.MarshalPKIXPublicKey(edPubKey)
 	if err != nil {
 		t.Fatal(err)
 	}
-	invalidKeyBytes := pem.EncodeToMemory(&pem.Block{
-		Bytes: invalidDer,
+	edKeyBytes := pem.EncodeToMemory(&pem.Block{
+		Bytes: edDer,
+		Type:  "PUBLIC KEY",
+	})
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaDer, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKeyBytes := pem.EncodeToMemory(&pem.Block{
+		Bytes: rsaDer,
 		Type:  "PUBLIC KEY",
 	})
 
@@ -95,9 +109,42 @@ func TestCrossFieldValidation(t *testing.T) {
 	signer, _ := signature.LoadSigner(key, crypto.SHA256)
 	sigBytes, _ := signer.SignMessage(bytes.NewReader(dataBytes))
 
+	// hashedrekord only ever stores a digest, so the Ed25519 signature here
+	// is computed over the digest bytes directly rather than the original
+	// message, matching how V001Entry.validate verifies it.
+	edSigner, _ := signature.LoadSigner(edPrivKey, crypto.Hash(0))
+	edSigBytes, _ := edSigner.SignMessage(bytes.NewReader(h[:]))
+
+	// A standards-compliant Ed25519 signer signs the artifact itself, not
+	// its digest; that signature must be rejected by the digest-only
+	// Verify contract (see pkg/pki/x509.Signature.Verify).
+	edSigOverMessageBytes, _ := edSigner.SignMessage(bytes.NewReader(dataBytes))
+
+	rsaPKCS1v15SigBytes, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPSSSigBytes, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, h[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h384 := sha512.Sum384(dataBytes)
+	dataSHA384 := hex.EncodeToString(h384[:])
+	signer384, _ := signature.LoadSigner(key, crypto.SHA384)
+	sigBytes384, _ := signer384.SignMessage(bytes.NewReader(dataBytes))
+
+	h512 := sha512.Sum512(dataBytes)
+	dataSHA512 := hex.EncodeToString(h512[:])
+	signer512, _ := signature.LoadSigner(key, crypto.SHA512)
+	sigBytes512, _ := signer512.SignMessage(bytes.NewReader(dataBytes))
+
 	incorrectLengthHash := sha256.Sum224(dataBytes)
 	incorrectLengthSHA := hex.EncodeToString(incorrectLengthHash[:])
 
+	// A value that is the right length for sha256 but wrong for sha384.
+	incorrectLength384SHA := dataSHA
+
 	badHash := sha256.Sum256(keyBytes)
 	badDataSHA := hex.EncodeToString(badHash[:])
 
@@ -144,14 +191,84 @@ func TestCrossFieldValidation(t *testing.T) {
 			entry: V001Entry{
 				HashedRekordObj: models.HashedrekordV001Schema{
 					Signature: &models.HashedrekordV001SchemaSignature{
-						Content: sigBytes,
+						Content: edSigBytes,
 						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
-							Content: invalidKeyBytes,
+							Content: edKeyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA),
+							Algorithm: swag.String(models.HashedrekordV001SchemaDataHashAlgorithmSha256),
 						},
 					},
 				},
 			},
-			expectUnmarshalSuccess: false,
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
+		{
+			caseDesc: "ed25519 signature over the raw message is rejected",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: edSigOverMessageBytes,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: edKeyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA),
+							Algorithm: swag.String(models.HashedrekordV001SchemaDataHashAlgorithmSha256),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    false,
+			expectCanonicalizeSuccess: false,
+		},
+		{
+			caseDesc: "signature with rsa public key and PKCS1v15 signature",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: rsaPKCS1v15SigBytes,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: rsaKeyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA),
+							Algorithm: swag.String(models.HashedrekordV001SchemaDataHashAlgorithmSha256),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
+		{
+			caseDesc: "signature with rsa public key and PSS signature",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: rsaPSSSigBytes,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: rsaKeyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA),
+							Algorithm: swag.String(models.HashedrekordV001SchemaDataHashAlgorithmSha256),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
 		},
 		{
 			caseDesc: "signature without data",
@@ -204,7 +321,7 @@ func TestCrossFieldValidation(t *testing.T) {
 			expectCanonicalizeSuccess: true,
 		},
 		{
-			caseDesc: "signature with invalid sha length",
+			caseDesc: "signature with invalid sha256 length",
 			entry: V001Entry{
 				HashedRekordObj: models.HashedrekordV001Schema{
 					Signature: &models.HashedrekordV001SchemaSignature{
@@ -224,6 +341,90 @@ func TestCrossFieldValidation(t *testing.T) {
 			expectUnmarshalSuccess:    false,
 			expectCanonicalizeSuccess: false,
 		},
+		{
+			caseDesc: "signature with invalid sha384 length",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: sigBytes384,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: keyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(incorrectLength384SHA),
+							Algorithm: swag.String("sha384"),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    false,
+			expectCanonicalizeSuccess: false,
+		},
+		{
+			caseDesc: "signature with unsupported hash algorithm",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: sigBytes,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: keyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA),
+							Algorithm: swag.String("md5"),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    false,
+			expectCanonicalizeSuccess: false,
+		},
+		{
+			caseDesc: "signature with sha384 hash",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: sigBytes384,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: keyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA384),
+							Algorithm: swag.String("sha384"),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
+		{
+			caseDesc: "signature with sha512 hash",
+			entry: V001Entry{
+				HashedRekordObj: models.HashedrekordV001Schema{
+					Signature: &models.HashedrekordV001SchemaSignature{
+						Content: sigBytes512,
+						PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+							Content: keyBytes,
+						},
+					},
+					Data: &models.HashedrekordV001SchemaData{
+						Hash: &models.HashedrekordV001SchemaDataHash{
+							Value:     swag.String(dataSHA512),
+							Algorithm: swag.String("sha512"),
+						},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
 		{
 			caseDesc: "signature with hash & invalid signature",
 			entry: V001Entry{
@@ -294,7 +495,7 @@ func TestCrossFieldValidation(t *testing.T) {
 
 func hexHash(b []byte) string {
 	h := sha256.Sum256([]byte(b))
-	return hex.EncodeToString(h[:])
+	return "sha256:" + hex.EncodeToString(h[:])
 }
 
 func TestV001Entry_IndexKeys(t *testing.T) {
@@ -370,6 +571,93 @@ func TestV001Entry_IndexKeys(t *testing.T) {
 		}
 	})
 
+	// For an Ed25519 public key, we should have the key hash index entry.
+	t.Run("ed25519 public key", func(t *testing.T) {
+		edPub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edDer, err := x509.MarshalPKIXPublicKey(edPub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edKeyBytes := pem.EncodeToMemory(&pem.Block{
+			Bytes: edDer,
+			Type:  "PUBLIC KEY",
+		})
+		v.HashedRekordObj.Signature.PublicKey.Content = strfmt.Base64(edKeyBytes)
+
+		k, err := v.IndexKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := map[string]struct{}{}
+		for _, key := range k {
+			keys[key] = struct{}{}
+		}
+
+		want := hexHash(edKeyBytes)
+		if _, ok := keys[want]; !ok {
+			t.Errorf("missing key index entry %s, got %v", want, keys)
+		}
+	})
+
+	// For an RSA public key, we should have the key hash index entry.
+	t.Run("rsa public key", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsaDer, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsaKeyBytes := pem.EncodeToMemory(&pem.Block{
+			Bytes: rsaDer,
+			Type:  "PUBLIC KEY",
+		})
+		v.HashedRekordObj.Signature.PublicKey.Content = strfmt.Base64(rsaKeyBytes)
+
+		k, err := v.IndexKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := map[string]struct{}{}
+		for _, key := range k {
+			keys[key] = struct{}{}
+		}
+
+		want := hexHash(rsaKeyBytes)
+		if _, ok := keys[want]; !ok {
+			t.Errorf("missing key index entry %s, got %v", want, keys)
+		}
+	})
+
+	t.Run("in-toto statement subjects", func(t *testing.T) {
+		statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"foo.tar.gz","digest":{"sha256":"4ac564241dda1bf8f98bc4ba4dbdfc8e8e4e8c4a5cf3c2d8ac4f78a4db9e5c99"}}],"predicateType":"https://slsa.dev/provenance/v0.2","predicate":{}}`)
+
+		inTotoV := v
+		inTotoV.HashedRekordObj.Data = &models.HashedrekordV001SchemaData{
+			Hash:    v.HashedRekordObj.Data.Hash,
+			Content: strfmt.Base64(statement),
+		}
+
+		k, err := inTotoV.IndexKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "sha256:4ac564241dda1bf8f98bc4ba4dbdfc8e8e4e8c4a5cf3c2d8ac4f78a4db9e5c99"
+		found := false
+		for _, key := range k {
+			if key == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing subject digest index key %s, got %v", want, k)
+		}
+	})
+
 }
 
 func testKeyAndCert(t *testing.T) ([]byte, []byte, *ecdsa.PrivateKey) {
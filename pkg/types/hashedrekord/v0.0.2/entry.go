@@ -0,0 +1,233 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package hashedrekord
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/pki"
+	"github.com/sigstore/rekor/pkg/pki/x509"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+const (
+	APIVERSION = "0.0.2"
+
+	inTotoPayloadType = "application/vnd.in-toto+json"
+)
+
+func init() {
+	if err := types.NewEntryType(APIVERSION, NewEntry); err != nil {
+		log.Logger.Panic(err)
+	}
+}
+
+// V002Entry is a hashedrekord entry whose signed content is a DSSE envelope
+// rather than a raw signature and public key. It exists alongside v0.0.1 so
+// that DSSE-wrapped in-toto attestations (e.g. from cosign's attest/verify
+// flows) can be logged as hashedrekord entries without a separate dsse kind.
+//
+// This breaks the storage contract every other hashedrekord version upholds:
+// v0.0.1 stores only a digest of the signed artifact and never the artifact
+// itself (see v0.0.1's Canonicalize). v0.0.2's Canonicalize, by contrast,
+// persists the entire DSSE Envelope - including its full in-toto Payload -
+// in the log. Reviewers of the "hashedrekord" OpenAPI schema should not
+// assume "hashedrekord" means "digest-only" once v0.0.2 is in play.
+type V002Entry struct {
+	HashedRekordObj models.HashedrekordV002Schema
+	keysObj         []pki.PublicKey
+	sigsObj         []pki.Signature
+	statement       *in_toto.Statement
+}
+
+func NewEntry() types.EntryImpl {
+	return &V002Entry{}
+}
+
+func (v V002Entry) APIVersion() string {
+	return APIVERSION
+}
+
+// Unmarshal decodes the supplied ProposedEntry's spec into the type's
+// internal schema representation and validates it.
+func (v *V002Entry) Unmarshal(pe interface{}) error {
+	entry, ok := pe.(*models.Hashedrekord)
+	if !ok {
+		return errors.New("cannot unmarshal non Hashedrekord types")
+	}
+
+	if err := types.DecodeEntry(entry.Spec, &v.HashedRekordObj); err != nil {
+		return err
+	}
+
+	_, _, err := v.validate()
+	return err
+}
+
+// parseEnvelope does the key/signature parsing and in-toto Statement
+// unmarshalling that both validate() and IndexKeys() need, without verifying
+// any signature, so that IndexKeys can still expose lookup keys for an
+// envelope whose signatures don't (or don't yet) verify.
+func (v *V002Entry) parseEnvelope() ([]pki.PublicKey, []pki.Signature, error) {
+	env := v.HashedRekordObj.Envelope
+	if env == nil {
+		return nil, nil, errors.New("missing envelope")
+	}
+	if len(env.Payload) == 0 {
+		return nil, nil, errors.New("envelope payload must be specified")
+	}
+	if swag.StringValue(env.PayloadType) == "" {
+		return nil, nil, errors.New("envelope payloadType must be specified")
+	}
+	if len(env.Signatures) == 0 {
+		return nil, nil, errors.New("envelope must contain at least one signature")
+	}
+
+	keys := make([]pki.PublicKey, 0, len(env.Signatures))
+	sigs := make([]pki.Signature, 0, len(env.Signatures))
+	for i, s := range env.Signatures {
+		if s == nil || len(s.Sig) == 0 {
+			return nil, nil, fmt.Errorf("signature %v is missing content", i)
+		}
+		if s.PublicKey == nil || len(s.PublicKey) == 0 {
+			return nil, nil, fmt.Errorf("signature %v is missing a public key", i)
+		}
+
+		keyObj, err := x509.NewPublicKey(bytes.NewReader(s.PublicKey))
+		if err != nil {
+			return nil, nil, err
+		}
+		sigObj, err := x509.NewSignature(bytes.NewReader(s.Sig))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, keyObj)
+		sigs = append(sigs, sigObj)
+	}
+
+	var stmt *in_toto.Statement
+	if swag.StringValue(env.PayloadType) == inTotoPayloadType {
+		stmt = &in_toto.Statement{}
+		if err := json.Unmarshal(env.Payload, stmt); err != nil {
+			return nil, nil, fmt.Errorf("unmarshalling in-toto statement: %w", err)
+		}
+	}
+
+	v.keysObj = keys
+	v.sigsObj = sigs
+	v.statement = stmt
+
+	return keys, sigs, nil
+}
+
+// validate PAE-encodes the envelope and checks every signature in it against
+// its accompanying public key.
+func (v *V002Entry) validate() ([]pki.PublicKey, []pki.Signature, error) {
+	keys, sigs, err := v.parseEnvelope()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	env := v.HashedRekordObj.Envelope
+	paeDigest := sha256.Sum256(dsse.PAE(swag.StringValue(env.PayloadType), env.Payload))
+
+	for i, sigObj := range sigs {
+		if err := sigObj.Verify(bytes.NewReader(paeDigest[:]), keys[i], crypto.SHA256); err != nil {
+			return nil, nil, fmt.Errorf("signature %v: %w", i, err)
+		}
+	}
+
+	return keys, sigs, nil
+}
+
+// Canonicalize validates the entry and marshals it into its canonical,
+// API-version-stamped form suitable for storage in the log.
+//
+// Unlike v0.0.1's Canonicalize, this persists the Envelope as-is, payload and
+// all - the full in-toto attestation, not just a digest of it - because the
+// signature is over the DSSE PAE encoding of the whole payload and cannot be
+// re-verified later from a digest alone. See the V002Entry doc comment.
+func (v *V002Entry) Canonicalize(_ context.Context) ([]byte, error) {
+	if _, _, err := v.validate(); err != nil {
+		return nil, types.ValidationError(err)
+	}
+
+	canonicalEntry := models.HashedrekordV002Schema{
+		Envelope: v.HashedRekordObj.Envelope,
+	}
+
+	itemBytes, err := swag.WriteJSON(canonicalEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	returnVal := models.Hashedrekord{
+		APIVersion: swag.String(APIVERSION),
+		Spec:       json.RawMessage(itemBytes),
+	}
+
+	return swag.WriteJSON(returnVal)
+}
+
+// IndexKeys returns a hash of each signer's public key, plus, when the
+// envelope's payload is an in-toto Statement, an "<alg>:<digest>" key for
+// every subject in the statement so consumers can look entries up by
+// artifact digest even though only the attestation was logged.
+func (v *V002Entry) IndexKeys() ([]string, error) {
+	var result []string
+
+	if v.keysObj == nil {
+		if _, _, err := v.parseEnvelope(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, keyObj := range v.keysObj {
+		key, err := keyObj.CanonicalValue()
+		if err != nil {
+			log.Logger.Error(err)
+			continue
+		}
+		keyHash := sha256.Sum256(key)
+		result = append(result, "sha256:"+hex.EncodeToString(keyHash[:]))
+	}
+
+	if v.statement != nil {
+		for _, subject := range v.statement.Subject {
+			for alg, digest := range subject.Digest {
+				result = append(result, strings.ToLower(fmt.Sprintf("%s:%s", alg, digest)))
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,240 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package hashedrekord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/swag"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+func TestNewEntryReturnType(t *testing.T) {
+	entry := NewEntry()
+	if reflect.TypeOf(entry) != reflect.ValueOf(&V002Entry{}).Type() {
+		t.Errorf("invalid type returned from NewEntry: %T", entry)
+	}
+}
+
+const inTotoPayload = `{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"foo.tar.gz","digest":{"sha256":"4ac564241dda1bf8f98bc4ba4dbdfc8e8e4e8c4a5cf3c2d8ac4f78a4db9e5c99"}}],"predicateType":"https://slsa.dev/provenance/v0.2","predicate":{}}`
+
+func signEnvelope(t *testing.T, payloadType string, payload []byte, key *ecdsa.PrivateKey) *models.HashedrekordV002SchemaEnvelopeSignaturesItems0 {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes := pem.EncodeToMemory(&pem.Block{Bytes: der, Type: "PUBLIC KEY"})
+
+	paeDigest := sha256.Sum256(dsse.PAE(payloadType, payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, paeDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &models.HashedrekordV002SchemaEnvelopeSignaturesItems0{
+		Sig:       sig,
+		PublicKey: keyBytes,
+	}
+}
+
+func TestCrossFieldValidation(t *testing.T) {
+	type TestCase struct {
+		caseDesc                  string
+		entry                     V002Entry
+		expectUnmarshalSuccess    bool
+		expectCanonicalizeSuccess bool
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(inTotoPayload)
+	goodSig := signEnvelope(t, inTotoPayloadType, payload, key)
+
+	badKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatchedSig := signEnvelope(t, inTotoPayloadType, payload, badKey)
+	mismatchedSig.PublicKey = goodSig.PublicKey
+
+	testCases := []TestCase{
+		{
+			caseDesc:               "empty obj",
+			entry:                  V002Entry{},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "envelope without payload",
+			entry: V002Entry{
+				HashedRekordObj: models.HashedrekordV002Schema{
+					Envelope: &models.HashedrekordV002SchemaEnvelope{
+						PayloadType: swag.String(inTotoPayloadType),
+						Signatures:  []*models.HashedrekordV002SchemaEnvelopeSignaturesItems0{goodSig},
+					},
+				},
+			},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "envelope without signatures",
+			entry: V002Entry{
+				HashedRekordObj: models.HashedrekordV002Schema{
+					Envelope: &models.HashedrekordV002SchemaEnvelope{
+						Payload:     payload,
+						PayloadType: swag.String(inTotoPayloadType),
+					},
+				},
+			},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "envelope with mismatched signature",
+			entry: V002Entry{
+				HashedRekordObj: models.HashedrekordV002Schema{
+					Envelope: &models.HashedrekordV002SchemaEnvelope{
+						Payload:     payload,
+						PayloadType: swag.String(inTotoPayloadType),
+						Signatures:  []*models.HashedrekordV002SchemaEnvelopeSignaturesItems0{mismatchedSig},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    false,
+			expectCanonicalizeSuccess: false,
+		},
+		{
+			caseDesc: "valid in-toto statement envelope",
+			entry: V002Entry{
+				HashedRekordObj: models.HashedrekordV002Schema{
+					Envelope: &models.HashedrekordV002SchemaEnvelope{
+						Payload:     payload,
+						PayloadType: swag.String(inTotoPayloadType),
+						Signatures:  []*models.HashedrekordV002SchemaEnvelopeSignaturesItems0{goodSig},
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		if _, _, err := tc.entry.validate(); (err == nil) != tc.expectUnmarshalSuccess {
+			t.Errorf("unexpected result in '%v': %v", tc.caseDesc, err)
+		}
+
+		v := &V002Entry{}
+		r := models.Hashedrekord{
+			APIVersion: swag.String(tc.entry.APIVersion()),
+			Spec:       tc.entry.HashedRekordObj,
+		}
+
+		unmarshalAndValidate := func() error {
+			if err := v.Unmarshal(&r); err != nil {
+				return err
+			}
+			if _, _, err := v.validate(); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		if err := unmarshalAndValidate(); (err == nil) != tc.expectUnmarshalSuccess {
+			t.Errorf("unexpected result in '%v': %v", tc.caseDesc, err)
+		}
+
+		b, err := v.Canonicalize(context.TODO())
+		if (err == nil) != tc.expectCanonicalizeSuccess {
+			t.Errorf("unexpected result from Canonicalize for '%v': %v", tc.caseDesc, err)
+		} else if err != nil {
+			if _, ok := err.(types.ValidationError); !ok {
+				t.Errorf("canonicalize returned an unexpected error that isn't of type types.ValidationError: %v", err)
+			}
+		}
+		if b != nil {
+			pe, err := models.UnmarshalProposedEntry(bytes.NewReader(b), runtime.JSONConsumer())
+			if err != nil {
+				t.Errorf("unexpected err from Unmarshalling canonicalized entry for '%v': %v", tc.caseDesc, err)
+			}
+			if _, err := types.UnmarshalEntry(pe); err != nil {
+				t.Errorf("unexpected err from type-specific unmarshalling for '%v': %v", tc.caseDesc, err)
+			}
+		}
+	}
+}
+
+func TestV002Entry_IndexKeys(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(inTotoPayload)
+	sig := signEnvelope(t, inTotoPayloadType, payload, key)
+
+	v := V002Entry{
+		HashedRekordObj: models.HashedrekordV002Schema{
+			Envelope: &models.HashedrekordV002SchemaEnvelope{
+				Payload:     payload,
+				PayloadType: swag.String(inTotoPayloadType),
+				Signatures:  []*models.HashedrekordV002SchemaEnvelopeSignaturesItems0{sig},
+			},
+		},
+	}
+
+	keys, err := v.IndexKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stmt struct {
+		Subject []struct {
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &stmt); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "sha256:" + stmt.Subject[0].Digest["sha256"]
+	found := false
+	for _, k := range keys {
+		if k == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("missing subject digest index key %s, got %v", want, keys)
+	}
+}